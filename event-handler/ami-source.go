@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// amiSource wraps the AMI TCP protocol (login, event parsing) as an
+// EventSource: Start manages its own reconnect/backoff via a Supervisor and
+// pushes every accepted event into the returned channel.
+type amiSource struct {
+	config     *Config
+	metrics    *Metrics
+	dispatcher *ActionDispatcher
+}
+
+func newAMISource(config *Config, metrics *Metrics, dispatcher *ActionDispatcher) *amiSource {
+	return &amiSource{config: config, metrics: metrics, dispatcher: dispatcher}
+}
+
+func (s *amiSource) Start(ctx context.Context) <-chan WebhookPayload {
+	out := make(chan WebhookPayload, 64)
+
+	go func() {
+		defer close(out)
+
+		supervisor := newSupervisor("ami", s.metrics)
+		supervisor.Run(ctx, func(ctx context.Context) error {
+			conn, err := connectToAMI(s.config)
+			if err != nil {
+				return fmt.Errorf("failed to connect to AMI: %w", err)
+			}
+			defer conn.Close()
+
+			s.dispatcher.SetConn(conn)
+			return handleAMIEvents(ctx, conn, s.config, out, s.dispatcher)
+		})
+	}()
+
+	return out
+}
+
+func (s *amiSource) Close() error { return nil }
+
+func connectToAMI(config *Config) (net.Conn, error) {
+	// Connect to AMI
+	conn, err := net.Dial("tcp", config.AsteriskHost+":"+config.AMIPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to AMI: %w", err)
+	}
+
+	// Read welcome message
+	reader := bufio.NewReader(conn)
+	welcome, _ := reader.ReadString('\n')
+	log.Printf("AMI Welcome: %s", strings.TrimSpace(welcome))
+
+	// Send login
+	loginMsg := fmt.Sprintf("Action: Login\r\nUsername: %s\r\nSecret: %s\r\n\r\n",
+		config.AMIUser, config.AMIPass)
+
+	if _, err := conn.Write([]byte(loginMsg)); err != nil {
+		return nil, fmt.Errorf("failed to send login: %w", err)
+	}
+
+	// Read login response
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read login response: %w", err)
+		}
+		line = strings.TrimSpace(line)
+
+		if strings.Contains(line, "Message: Authentication accepted") {
+			log.Println("Successfully authenticated with AMI")
+			break
+		}
+		if strings.Contains(line, "Message: Authentication failed") {
+			return nil, fmt.Errorf("AMI authentication failed")
+		}
+		if line == "" {
+			break
+		}
+	}
+
+	return conn, nil
+}
+
+func parseAMIEvent(eventText string) (string, map[string]interface{}) {
+	lines := strings.Split(eventText, "\r\n")
+	eventData := make(map[string]interface{})
+	var eventType string
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ": ", 2)
+		if len(parts) == 2 {
+			key := parts[0]
+			value := parts[1]
+
+			if key == "Event" {
+				eventType = value
+			}
+			eventData[key] = value
+		}
+	}
+
+	return eventType, eventData
+}
+
+func handleAMIEvents(ctx context.Context, conn net.Conn, config *Config, out chan<- WebhookPayload, dispatcher *ActionDispatcher) error {
+	log.Println("Starting AMI event handler - listening for ALL Asterisk events...")
+	reader := bufio.NewReader(conn)
+	eventCount := 0
+	lastHeartbeat := time.Now()
+
+	var currentEvent strings.Builder
+
+	// Set read timeout
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// A read timeout on an idle connection doesn't mean the
+			// connection is dead - probe it with a Ping instead of
+			// forcing the Supervisor to reconnect and re-login.
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				if _, werr := conn.Write([]byte("Action: Ping\r\n\r\n")); werr != nil {
+					return fmt.Errorf("AMI keepalive ping failed: %w", werr)
+				}
+				conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+				continue
+			}
+			return fmt.Errorf("error reading AMI message: %w", err)
+		}
+
+		// Reset read deadline
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		line = strings.TrimSpace(line)
+
+		// Heartbeat every 60 seconds
+		if time.Since(lastHeartbeat) > 60*time.Second {
+			log.Printf("AMI Heartbeat: Connection alive, processed %d events", eventCount)
+			lastHeartbeat = time.Now()
+		}
+
+		// Empty line indicates end of event
+		if line == "" {
+			eventText := currentEvent.String()
+			if eventText != "" {
+				// Parse the block. A synchronous Originate's reply is an
+				// action response (ActionID, no Event:), not an event, so
+				// this must run before the Event: allowlist check below.
+				eventType, eventData := parseAMIEvent(eventText)
+
+				// Hand the block to any Originate call awaiting its
+				// response, whether it's an action response or an event.
+				dispatcher.dispatch(eventData)
+
+				// With a routing config, sinks declare their own event
+				// filters; otherwise fall back to the built-in allowlist.
+				if strings.Contains(eventText, "Event:") && (config.RoutesConfig != "" || shouldProcessEvent(eventType)) {
+					eventCount++
+					log.Printf("📞 Received AMI event #%d: %s", eventCount, eventType)
+
+					payload := WebhookPayload{
+						Source:    "asterisk-ami",
+						EventType: eventType,
+						Timestamp: time.Now().UTC(),
+						Data:      eventData,
+					}
+
+					select {
+					case out <- payload:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			}
+
+			// Reset for next event
+			currentEvent.Reset()
+			continue
+		}
+
+		// Add line to current event
+		currentEvent.WriteString(line + "\r\n")
+	}
+}
+
+func shouldProcessEvent(eventType string) bool {
+	// Process all call-related events
+	callEvents := []string{
+		"Newchannel", "Hangup", "DialBegin", "DialEnd", "Bridge", "Unbridge",
+		"NewCallerid", "NewAccountCode", "NewExten", "NewState", "Dial",
+		"AgentCalled", "AgentConnect", "QueueMemberAdded", "QueueMemberRemoved",
+		"Hold", "Unhold", "MusicOnHoldStart", "MusicOnHoldStop", "Transfer",
+		"AttendedTransfer", "BlindTransfer", "DTMF", "VoicemailUserEntry",
+		"CEL", "CDR", "LocalBridge", "LocalOptimizationBegin", "LocalOptimizationEnd",
+		"OriginateResponse", "ChannelTalkingStart", "ChannelTalkingStop",
+		"BridgeCreate", "BridgeDestroy", "BridgeEnter", "BridgeLeave",
+		"VarSet", "UserEvent", "Registry", "PeerStatus", "ContactStatus",
+	}
+
+	for _, event := range callEvents {
+		if eventType == event {
+			return true
+		}
+	}
+
+	return false
+}
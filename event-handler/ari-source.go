@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ariSource wraps the ARI application registration and events WebSocket as
+// an EventSource: Start manages its own reconnect/backoff via a Supervisor,
+// re-registering the application on every reconnect, and pushes every
+// decoded event into the returned channel.
+type ariSource struct {
+	config  *Config
+	metrics *Metrics
+}
+
+func newARISource(config *Config, metrics *Metrics) *ariSource {
+	return &ariSource{config: config, metrics: metrics}
+}
+
+func (s *ariSource) Start(ctx context.Context) <-chan WebhookPayload {
+	out := make(chan WebhookPayload, 64)
+
+	go func() {
+		defer close(out)
+
+		supervisor := newSupervisor("ari", s.metrics)
+		supervisor.Run(ctx, func(ctx context.Context) error {
+			if err := registerApplication(s.config); err != nil {
+				return fmt.Errorf("failed to register ARI application: %w", err)
+			}
+
+			conn, err := connectToARI(s.config)
+			if err != nil {
+				return fmt.Errorf("failed to connect to ARI: %w", err)
+			}
+			defer conn.Close()
+
+			return handleEvents(ctx, conn, out)
+		})
+	}()
+
+	return out
+}
+
+func (s *ariSource) Close() error { return nil }
+
+func connectToARI(config *Config) (*websocket.Conn, error) {
+	// Build WebSocket URL for ARI events
+	u := url.URL{
+		Scheme: "ws",
+		Host:   config.ARIHost + ":" + config.ARIPort,
+		Path:   "/ari/events",
+		RawQuery: fmt.Sprintf("app=%s&api_key=%s:%s",
+			config.ARIAppName, config.ARIUser, config.ARIPass),
+	}
+
+	log.Printf("Connecting to ARI WebSocket: %s", u.String())
+
+	// Set up WebSocket connection
+	dialer := websocket.DefaultDialer
+	dialer.HandshakeTimeout = 10 * time.Second
+
+	conn, _, err := dialer.Dial(u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ARI WebSocket: %w", err)
+	}
+
+	log.Println("Successfully connected to Asterisk ARI")
+	return conn, nil
+}
+
+func registerApplication(config *Config) error {
+	// Register the ARI application
+	apiURL := fmt.Sprintf("http://%s:%s/ari/applications/%s",
+		config.ARIHost, config.ARIPort, config.ARIAppName)
+
+	req, err := http.NewRequest("PUT", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create application registration request: %w", err)
+	}
+
+	req.SetBasicAuth(config.ARIUser, config.ARIPass)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to register ARI application: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to register ARI application, status: %d", resp.StatusCode)
+	}
+
+	log.Printf("Successfully registered ARI application: %s", config.ARIAppName)
+	return nil
+}
+
+func handleEvents(ctx context.Context, conn *websocket.Conn, out chan<- WebhookPayload) error {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("error reading WebSocket message: %w", err)
+		}
+
+		// Parse the ARI event
+		var event map[string]interface{}
+		if err := json.Unmarshal(message, &event); err != nil {
+			log.Printf("Error parsing ARI event JSON: %v", err)
+			continue
+		}
+
+		// Extract event type
+		eventType, ok := event["type"].(string)
+		if !ok {
+			log.Println("Event missing type field, skipping")
+			continue
+		}
+
+		payload := WebhookPayload{
+			Source:    "asterisk-ari",
+			EventType: eventType,
+			Timestamp: time.Now().UTC(),
+			Data:      event,
+		}
+
+		select {
+		case out <- payload:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
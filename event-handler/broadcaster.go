@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// subscriberBufferSize bounds how far a subscriber can lag behind the
+// ingest loop before it's treated as slow and disconnected.
+const subscriberBufferSize = 32
+
+// wsPongWait is how long a WS connection may stay silent before it's
+// considered dead; wsPingPeriod (well under wsPongWait) keeps it alive.
+const (
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = 50 * time.Second
+)
+
+var broadcasterUpgrader = websocket.Upgrader{
+	// Local debugging/dashboard endpoint, not exposed beyond the host.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type broadcastSubscriber struct {
+	id      int64
+	ch      chan WebhookPayload
+	events  map[string]bool
+	sources map[string]bool
+}
+
+func (s *broadcastSubscriber) matches(payload WebhookPayload) bool {
+	if s.events != nil && !s.events[payload.EventType] {
+		return false
+	}
+	if s.sources != nil && !s.sources[sourceTag(payload.Source)] {
+		return false
+	}
+	return true
+}
+
+type subscribeRequest struct {
+	events  map[string]bool
+	sources map[string]bool
+	resp    chan *broadcastSubscriber
+}
+
+// Broadcaster fans every accepted event out to local live subscribers
+// (WebSocket or SSE) in addition to whatever sink the event was published
+// to. A single goroutine (Run) owns the subscriber set, so registration,
+// unregistration and fanout never race; a subscriber that can't keep up is
+// disconnected outright rather than silently losing a subset of events
+// forever or blocking the ingest loop.
+type Broadcaster struct {
+	register    chan *subscribeRequest
+	unregister  chan int64
+	publishCh   chan WebhookPayload
+	subscribers map[int64]*broadcastSubscriber
+	nextID      int64
+}
+
+func newBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		register:    make(chan *subscribeRequest),
+		unregister:  make(chan int64),
+		publishCh:   make(chan WebhookPayload, 256),
+		subscribers: make(map[int64]*broadcastSubscriber),
+	}
+}
+
+// Run owns the subscriber set until ctx is cancelled. Intended to be run
+// in its own goroutine alongside the rest of main.
+func (b *Broadcaster) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-b.register:
+			b.nextID++
+			sub := &broadcastSubscriber{
+				id:      b.nextID,
+				ch:      make(chan WebhookPayload, subscriberBufferSize),
+				events:  req.events,
+				sources: req.sources,
+			}
+			b.subscribers[sub.id] = sub
+			req.resp <- sub
+		case id := <-b.unregister:
+			if sub, ok := b.subscribers[id]; ok {
+				close(sub.ch)
+				delete(b.subscribers, id)
+			}
+		case payload := <-b.publishCh:
+			for id, sub := range b.subscribers {
+				if !sub.matches(payload) {
+					continue
+				}
+				select {
+				case sub.ch <- payload:
+				default:
+					log.Printf("broadcaster: subscriber %d is slow, disconnecting", id)
+					close(sub.ch)
+					delete(b.subscribers, id)
+				}
+			}
+		}
+	}
+}
+
+// Publish hands payload to the broadcaster goroutine. Non-blocking: if the
+// broadcaster itself is backed up, the event is dropped rather than
+// stalling the caller's ingest loop.
+func (b *Broadcaster) Publish(payload WebhookPayload) {
+	select {
+	case b.publishCh <- payload:
+	default:
+		log.Printf("broadcaster: publish queue full, dropping event %q", payload.EventType)
+	}
+}
+
+func (b *Broadcaster) subscribe(events, sources map[string]bool) *broadcastSubscriber {
+	resp := make(chan *broadcastSubscriber, 1)
+	b.register <- &subscribeRequest{events: events, sources: sources, resp: resp}
+	return <-resp
+}
+
+func (b *Broadcaster) unsubscribe(id int64) {
+	b.unregister <- id
+}
+
+func parseFilterSet(values string) map[string]bool {
+	if values == "" {
+		return nil
+	}
+
+	set := make(map[string]bool)
+	for _, v := range strings.Split(values, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			set[v] = true
+		}
+	}
+
+	return set
+}
+
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+token
+}
+
+func (b *Broadcaster) handleWS(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := broadcasterUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("broadcaster: websocket upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		sub := b.subscribe(parseFilterSet(r.URL.Query().Get("event")), parseFilterSet(r.URL.Query().Get("source")))
+		defer b.unsubscribe(sub.id)
+
+		// A client that goes away without a clean close (network drop,
+		// laptop sleep) never errors out of WriteJSON on its own; read
+		// pings/pongs and bound idle time so a dead connection is reaped
+		// instead of leaking the subscriber and this goroutine forever.
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			return nil
+		})
+		readDone := make(chan struct{})
+		go func() {
+			defer close(readDone)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(wsPingPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-readDone:
+				return
+			case <-ticker.C:
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			case payload, ok := <-sub.ch:
+				if !ok {
+					return
+				}
+				if err := conn.WriteJSON(payload); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *Broadcaster) handleSSE(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		sub := b.subscribe(parseFilterSet(r.URL.Query().Get("event")), parseFilterSet(r.URL.Query().Get("source")))
+		defer b.unsubscribe(sub.id)
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case payload, ok := <-sub.ch:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(payload)
+				if err != nil {
+					log.Printf("broadcaster: failed to marshal SSE payload: %v", err)
+					continue
+				}
+				fmt.Fprintf(w, "data: %s\n\n", data)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// Start serves /ws and /events on addr. Meant to run in its own goroutine;
+// it blocks until the listener fails.
+func (b *Broadcaster) Start(addr, token string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", b.handleWS(token))
+	mux.HandleFunc("/events", b.handleSSE(token))
+
+	log.Printf("Broadcaster listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Broadcaster server stopped: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// EventSource produces a single stream of WebhookPayloads, managing its own
+// connection, authentication and reconnect/backoff internally. Start may be
+// called once; the returned channel is closed once ctx is done or the
+// source is permanently unable to continue.
+type EventSource interface {
+	Start(ctx context.Context) <-chan WebhookPayload
+	Close() error
+}
+
+func main() {
+	log.Println("Starting Asterisk Webhook Forwarder...")
+
+	config := loadConfig()
+
+	// Set up the event publisher
+	publisher, err := newPublisher(config)
+	if err != nil {
+		log.Fatalf("Failed to set up publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	// With QUEUE_DIR set, events are appended to a durable on-disk queue and
+	// a worker pool drains it into the real publisher with retry/backoff;
+	// otherwise events are delivered to the publisher inline as before.
+	sink := publisher
+	metrics := newMetrics()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if config.QueueDir != "" {
+		queue, err := openQueue(config.QueueDir, config.QueueMaxBytes)
+		if err != nil {
+			log.Fatalf("Failed to open event queue: %v", err)
+		}
+
+		sink = &QueueingPublisher{queue: queue, metrics: metrics}
+		pool := newQueueWorkerPool(queue, publisher, metrics, config.QueueWorkers)
+		go pool.Run(ctx)
+	}
+
+	go metrics.Start(config.MetricsListen)
+
+	// Local WebSocket/SSE subscribers get the same events as the
+	// configured sink, in parallel.
+	broadcaster := newBroadcaster()
+	go broadcaster.Run(ctx)
+	go broadcaster.Start(config.WSListen, config.WSToken)
+
+	// SOURCES selects which EventSources to run; a single process can run
+	// either or both, merging them into one delivery pipeline below.
+	var dispatcher *ActionDispatcher
+	var sources []EventSource
+	for _, name := range config.Sources {
+		switch name {
+		case "ami":
+			dispatcher = newActionDispatcher()
+			sources = append(sources, newAMISource(config, metrics, dispatcher))
+		case "ari":
+			sources = append(sources, newARISource(config, metrics))
+		default:
+			log.Printf("unknown source %q in SOURCES, skipping", name)
+		}
+	}
+	if len(sources) == 0 {
+		log.Fatal("no valid sources configured; set SOURCES to a comma-separated list of ami,ari")
+	}
+
+	// The Originate REST API rides along the AMI connection, so it's only
+	// available when the ami source is enabled.
+	if dispatcher != nil {
+		originateAPI := newOriginateAPI(config, dispatcher)
+		go originateAPI.Start()
+	}
+
+	// Merge every source's events into a single delivery pipeline: each
+	// accepted event is handed to the sink and broadcast to local
+	// subscribers exactly once, regardless of which source produced it.
+	merged := make(chan WebhookPayload, 256)
+	var wg sync.WaitGroup
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source EventSource) {
+			defer wg.Done()
+			for payload := range source.Start(ctx) {
+				merged <- payload
+			}
+		}(source)
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	go func() {
+		for payload := range merged {
+			// With no queue, sink is the real publisher and this delivers
+			// it directly; with a queue, delivery (and the metrics count)
+			// happens later in the worker pool, so this only enqueues.
+			var err error
+			if config.QueueDir == "" {
+				err = deliver(context.Background(), sink, metrics, payload)
+			} else {
+				err = sink.Publish(context.Background(), payload)
+			}
+			if err != nil {
+				log.Printf("Failed to queue/publish event '%s': %v", payload.EventType, err)
+			} else {
+				log.Printf("Successfully queued/published event '%s'", payload.EventType)
+			}
+			broadcaster.Publish(payload)
+		}
+	}()
+
+	// Set up graceful shutdown
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	log.Println("Webhook forwarder is running. Press Ctrl+C to stop...")
+
+	<-interrupt
+	log.Println("Shutting down gracefully...")
+	cancel()
+	for _, source := range sources {
+		source.Close()
+	}
+
+	log.Println("Shutdown complete")
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ActionDispatcher sends AMI actions over the current AMI connection and
+// correlates their asynchronous response events back to the caller via
+// ActionID. The underlying connection is swapped out by SetConn whenever
+// the Supervisor reconnects, so in-flight API requests survive a reconnect.
+type ActionDispatcher struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	pending map[string]chan map[string]interface{}
+	counter uint64
+}
+
+func newActionDispatcher() *ActionDispatcher {
+	return &ActionDispatcher{
+		pending: make(map[string]chan map[string]interface{}),
+	}
+}
+
+// SetConn swaps the connection used to send actions. Called by the
+// Supervisor each time it (re)establishes the AMI connection.
+func (d *ActionDispatcher) SetConn(conn net.Conn) {
+	d.mu.Lock()
+	d.conn = conn
+	d.mu.Unlock()
+}
+
+func (d *ActionDispatcher) nextActionID() string {
+	n := atomic.AddUint64(&d.counter, 1)
+	return fmt.Sprintf("originate-%d-%d", time.Now().UnixNano(), n)
+}
+
+// dispatch routes a parsed AMI event to its waiting caller, if any. Called
+// from handleAMIEvents for every event, regardless of the webhook allowlist.
+func (d *ActionDispatcher) dispatch(eventData map[string]interface{}) {
+	actionID, _ := eventData["ActionID"].(string)
+	if actionID == "" {
+		return
+	}
+
+	d.mu.Lock()
+	resultCh, ok := d.pending[actionID]
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	select {
+	case resultCh <- eventData:
+	default:
+	}
+}
+
+// Originate sends an AMI Originate action and blocks until its result is
+// known or timeout elapses. A synchronous Originate (the default) is
+// answered by a single action response carrying the real disposition. An
+// async Originate is instead answered by an immediate "Response: Success"
+// action ack followed later by the OriginateResponse event that carries
+// the actual Success/Failure/Busy outcome - dispatch delivers both, so
+// Originate must wait for the event rather than returning on the ack.
+func (d *ActionDispatcher) Originate(req OriginateRequest, timeout time.Duration) (map[string]interface{}, error) {
+	actionID := d.nextActionID()
+	resultCh := make(chan map[string]interface{}, 2)
+
+	d.mu.Lock()
+	d.pending[actionID] = resultCh
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.pending, actionID)
+		d.mu.Unlock()
+	}()
+
+	var sb strings.Builder
+	sb.WriteString("Action: Originate\r\n")
+	sb.WriteString("ActionID: " + actionID + "\r\n")
+	sb.WriteString("Channel: " + req.Channel + "\r\n")
+	if req.Context != "" {
+		sb.WriteString("Context: " + req.Context + "\r\n")
+	}
+	if req.Exten != "" {
+		sb.WriteString("Exten: " + req.Exten + "\r\n")
+	}
+	if req.Priority != 0 {
+		sb.WriteString(fmt.Sprintf("Priority: %d\r\n", req.Priority))
+	}
+	if req.CallerID != "" {
+		sb.WriteString("CallerID: " + req.CallerID + "\r\n")
+	}
+	if req.Timeout != 0 {
+		sb.WriteString(fmt.Sprintf("Timeout: %d\r\n", req.Timeout))
+	}
+	if req.Async {
+		sb.WriteString("Async: true\r\n")
+	}
+	for key, value := range req.Variables {
+		sb.WriteString(fmt.Sprintf("Variable: %s=%s\r\n", key, value))
+	}
+	sb.WriteString("\r\n")
+
+	d.mu.Lock()
+	conn := d.conn
+	d.mu.Unlock()
+	if conn == nil {
+		return nil, fmt.Errorf("not connected to AMI")
+	}
+
+	if _, err := conn.Write([]byte(sb.String())); err != nil {
+		return nil, fmt.Errorf("failed to send Originate action: %w", err)
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case result := <-resultCh:
+			if !req.Async {
+				return result, nil
+			}
+			if eventType, _ := result["Event"].(string); eventType == "OriginateResponse" {
+				return result, nil
+			}
+			// The immediate async action ack; keep waiting for the event.
+		case <-deadline:
+			return nil, fmt.Errorf("timed out waiting for OriginateResponse (ActionID %s)", actionID)
+		}
+	}
+}
+
+// OriginateRequest is the body of POST /calls/originate.
+type OriginateRequest struct {
+	Channel   string            `json:"channel"`
+	Context   string            `json:"context"`
+	Exten     string            `json:"exten"`
+	Priority  int               `json:"priority"`
+	Variables map[string]string `json:"variables"`
+	CallerID  string            `json:"callerid"`
+	Timeout   int               `json:"timeout"`
+	Async     bool              `json:"async"`
+}
+
+// OriginateResult is the JSON response for both /calls/originate and
+// /calls/spool once the matching event is received or a timeout elapses.
+type OriginateResult struct {
+	ActionID string `json:"action_id"`
+	Channel  string `json:"channel"`
+	Response string `json:"response"`
+}
+
+// SpoolRequest is the body of POST /calls/spool.
+type SpoolRequest struct {
+	Channel   string            `json:"channel"`
+	Context   string            `json:"context"`
+	Exten     string            `json:"exten"`
+	Priority  int               `json:"priority"`
+	Variables map[string]string `json:"variables"`
+	CallerID  string            `json:"callerid"`
+	Timeout   int               `json:"timeout"`
+}
+
+// OriginateAPI exposes the outgoing-call REST endpoints described in the
+// backlog: AMI Originate, an ARI /channels proxy, and spool-file submission.
+type OriginateAPI struct {
+	config     *Config
+	dispatcher *ActionDispatcher
+	httpClient *http.Client
+}
+
+func newOriginateAPI(config *Config, dispatcher *ActionDispatcher) *OriginateAPI {
+	return &OriginateAPI{
+		config:     config,
+		dispatcher: dispatcher,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (a *OriginateAPI) handleOriginate(w http.ResponseWriter, r *http.Request) {
+	var req OriginateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Channel == "" {
+		http.Error(w, "channel is required", http.StatusBadRequest)
+		return
+	}
+
+	timeout := 30 * time.Second
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+
+	result, err := a.dispatcher.Originate(req, timeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	}
+
+	actionID, _ := result["ActionID"].(string)
+	response, _ := result["Response"].(string)
+	writeJSON(w, http.StatusOK, OriginateResult{
+		ActionID: actionID,
+		Channel:  req.Channel,
+		Response: response,
+	})
+}
+
+func (a *OriginateAPI) handleARIChannels(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	apiURL := fmt.Sprintf("http://%s:%s/ari/channels?api_key=%s:%s",
+		a.config.ARIHost, a.config.ARIPort, a.config.ARIUser, a.config.ARIPass)
+
+	proxyReq, err := http.NewRequestWithContext(r.Context(), "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to build ARI request: %v", err), http.StatusInternalServerError)
+		return
+	}
+	proxyReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(proxyReq)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to reach ARI: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func (a *OriginateAPI) handleSpool(w http.ResponseWriter, r *http.Request) {
+	var req SpoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Channel == "" {
+		http.Error(w, "channel is required", http.StatusBadRequest)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Channel: " + req.Channel + "\n")
+	if req.Context != "" {
+		sb.WriteString("Context: " + req.Context + "\n")
+	}
+	if req.Exten != "" {
+		sb.WriteString("Extension: " + req.Exten + "\n")
+	}
+	if req.Priority != 0 {
+		sb.WriteString(fmt.Sprintf("Priority: %d\n", req.Priority))
+	}
+	if req.CallerID != "" {
+		sb.WriteString("CallerID: " + req.CallerID + "\n")
+	}
+	if req.Timeout != 0 {
+		sb.WriteString(fmt.Sprintf("WaitTime: %d\n", req.Timeout))
+	}
+	for key, value := range req.Variables {
+		sb.WriteString(fmt.Sprintf("SetVar: %s=%s\n", key, value))
+	}
+
+	fileName := fmt.Sprintf("webhook-%d.call", time.Now().UnixNano())
+	finalPath := filepath.Join(a.config.SpoolDir, fileName)
+
+	// Write the temp file in a sibling directory, not SpoolDir itself:
+	// Asterisk's spool scanner polls SpoolDir regardless of extension and
+	// will happily pick up a half-written ".tmp" file.
+	if err := os.MkdirAll(a.config.SpoolTmpDir, 0755); err != nil {
+		http.Error(w, fmt.Sprintf("failed to create spool tmp dir: %v", err), http.StatusInternalServerError)
+		return
+	}
+	tmpPath := filepath.Join(a.config.SpoolTmpDir, fileName+".tmp")
+
+	if err := os.WriteFile(tmpPath, []byte(sb.String()), 0644); err != nil {
+		http.Error(w, fmt.Sprintf("failed to write call file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		os.Remove(tmpPath)
+		http.Error(w, fmt.Sprintf("failed to finalize call file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"file": finalPath})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Start runs the Originate API HTTP server. Intended to be run in its own
+// goroutine; it blocks until the listener fails.
+func (a *OriginateAPI) Start() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/calls/originate", a.handleOriginate)
+	mux.HandleFunc("/ari/channels", a.handleARIChannels)
+	mux.HandleFunc("/calls/spool", a.handleSpool)
+
+	log.Printf("Originate API listening on %s", a.config.HTTPListen)
+	if err := http.ListenAndServe(a.config.HTTPListen, mux); err != nil {
+		log.Printf("Originate API server stopped: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// WebhookPayload is the event envelope delivered to every sink and
+// broadcast to every live subscriber, regardless of which EventSource
+// produced it.
+type WebhookPayload struct {
+	Source    string      `json:"source"`
+	EventType string      `json:"event_type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// sourceTag turns a payload's Source ("asterisk-ami", "asterisk-ari") into
+// the short tag used in subjects and routing keys ("ami", "ari").
+func sourceTag(source string) string {
+	return strings.TrimPrefix(source, "asterisk-")
+}
+
+// Publisher delivers a parsed event to some downstream sink. Implementations
+// must be safe to call repeatedly from the reconnect/retry loop.
+type Publisher interface {
+	Publish(ctx context.Context, payload WebhookPayload) error
+	Close() error
+}
+
+// deliver publishes payload to the real (non-queueing) Publisher and counts
+// it in metrics on success. Shared by main's inline delivery loop and the
+// queue worker pool so asterisk_forwarder_events_total reflects every event
+// actually delivered, regardless of whether QUEUE_DIR is enabled.
+func deliver(ctx context.Context, publisher Publisher, metrics *Metrics, payload WebhookPayload) error {
+	if err := publisher.Publish(ctx, payload); err != nil {
+		return err
+	}
+	metrics.IncEvent(payload.EventType)
+	return nil
+}
+
+// HTTPPublisher is today's behavior: POST the payload to a single webhook URL.
+type HTTPPublisher struct {
+	URL string
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, payload WebhookPayload) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.URL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Asterisk-Webhook-Forwarder/1.0")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned non-success status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (p *HTTPPublisher) Close() error { return nil }
+
+// NATSPublisher publishes to subject "asterisk.<source>.<eventType>". When
+// Stream is set it publishes through JetStream for at-least-once delivery.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	Stream string
+}
+
+func newNATSPublisher(config *Config) (*NATSPublisher, error) {
+	conn, err := nats.Connect(config.NATSURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	p := &NATSPublisher{conn: conn, Stream: config.NATSStream}
+	if config.NATSStream != "" {
+		js, err := conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to get JetStream context: %w", err)
+		}
+		p.js = js
+
+		if err := ensureStream(js, config.NATSStream); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// ensureStream creates the JetStream stream backing NATSStream if it
+// doesn't already exist, so publishing doesn't silently fall back to
+// at-most-once delivery against an unprovisioned stream.
+func ensureStream(js nats.JetStreamContext, name string) error {
+	if _, err := js.StreamInfo(name); err == nil {
+		return nil
+	}
+
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:     name,
+		Subjects: []string{"asterisk.>"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create JetStream stream %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, payload WebhookPayload) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	subject := fmt.Sprintf("asterisk.%s.%s", sourceTag(payload.Source), payload.EventType)
+	if p.js != nil {
+		_, err = p.js.Publish(subject, jsonData)
+	} else {
+		err = p.conn.Publish(subject, jsonData)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
+
+// RabbitMQPublisher publishes persistent messages to a topic exchange with
+// routing key "<source>.<eventType>".
+type RabbitMQPublisher struct {
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	exchange string
+}
+
+func newRabbitMQPublisher(config *Config) (*RabbitMQPublisher, error) {
+	conn, err := amqp.Dial(config.AMQPURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+
+	if err := channel.ExchangeDeclare(
+		config.AMQPExchange, // name
+		"topic",             // kind
+		config.AMQPDurable,  // durable
+		false,               // auto-deleted
+		false,               // internal
+		false,               // no-wait
+		nil,                 // args
+	); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare RabbitMQ exchange: %w", err)
+	}
+
+	return &RabbitMQPublisher{conn: conn, channel: channel, exchange: config.AMQPExchange}, nil
+}
+
+func (p *RabbitMQPublisher) Publish(ctx context.Context, payload WebhookPayload) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	routingKey := fmt.Sprintf("%s.%s", sourceTag(payload.Source), payload.EventType)
+	err = p.channel.PublishWithContext(ctx, p.exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         jsonData,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to RabbitMQ: %w", err)
+	}
+
+	return nil
+}
+
+func (p *RabbitMQPublisher) Close() error {
+	p.channel.Close()
+	return p.conn.Close()
+}
+
+func newPublisher(config *Config) (Publisher, error) {
+	if config.RoutesConfig != "" {
+		return newRouter(config.RoutesConfig)
+	}
+
+	switch config.Publisher {
+	case "", "http":
+		return &HTTPPublisher{URL: config.WebhookURL}, nil
+	case "nats":
+		return newNATSPublisher(config)
+	case "rabbitmq":
+		return newRabbitMQPublisher(config)
+	default:
+		return nil, fmt.Errorf("unknown PUBLISHER backend: %q", config.Publisher)
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queueSegmentBytes caps how large a single on-disk segment grows before
+// the queue rolls to a new one.
+const queueSegmentBytes = 8 * 1024 * 1024
+
+type queuePosition struct {
+	seq    int
+	offset int64
+}
+
+// Queue is an append-only, segmented on-disk log of WebhookPayloads sitting
+// between event parsing and sink delivery, so events survive sink outages
+// and process restarts. A small index file tracks the position of the last
+// acknowledged record; offsets only advance once a worker has durably
+// delivered the corresponding event.
+type Queue struct {
+	dir      string
+	maxBytes int64
+
+	mu           sync.Mutex
+	writer       *os.File
+	writeSeq     int
+	writeLen     int64
+	reader       *os.File
+	readSeq      int
+	readOffset   int64
+	nextID       int64
+	nextToCommit int64
+	enqueued     int64
+	diskBytes    int64
+	positions    map[int64]queuePosition
+	acked        map[int64]bool
+}
+
+// openQueue opens (or creates) the on-disk queue rooted at dir. maxBytes
+// bounds the total size of unacknowledged records kept on disk; once
+// reached, Enqueue rejects new records rather than growing without limit.
+// maxBytes <= 0 means unbounded.
+func openQueue(dir string, maxBytes int64) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create queue dir %s: %w", dir, err)
+	}
+
+	q := &Queue{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		positions:    make(map[int64]queuePosition),
+		acked:        make(map[int64]bool),
+		nextToCommit: 1,
+	}
+
+	seq, offset := q.loadIndex()
+	q.readSeq = seq
+	q.readOffset = offset
+
+	segs, err := q.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	writeSeq := q.readSeq
+	if len(segs) > 0 {
+		writeSeq = segs[len(segs)-1]
+	}
+	if err := q.openWriter(writeSeq); err != nil {
+		return nil, err
+	}
+
+	backlog, err := q.countBacklog(segs)
+	if err != nil {
+		return nil, err
+	}
+	q.enqueued = backlog
+
+	diskBytes, err := q.diskUsage(segs)
+	if err != nil {
+		return nil, err
+	}
+	q.diskBytes = diskBytes
+
+	return q, nil
+}
+
+// diskUsage sums the size of every segment still on disk.
+func (q *Queue) diskUsage(segs []int) (int64, error) {
+	var total int64
+	for _, seq := range segs {
+		info, err := os.Stat(q.segmentPath(seq))
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat queue segment %d: %w", seq, err)
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// countBacklog counts records already on disk between the last committed
+// read position and the end of the log, so Depth reflects an existing
+// backlog immediately after a restart rather than starting from zero.
+func (q *Queue) countBacklog(segs []int) (int64, error) {
+	var count int64
+	for _, seq := range segs {
+		if seq < q.readSeq {
+			continue
+		}
+
+		f, err := os.Open(q.segmentPath(seq))
+		if err != nil {
+			return 0, fmt.Errorf("failed to open queue segment %d: %w", seq, err)
+		}
+
+		if seq == q.readSeq && q.readOffset > 0 {
+			if _, err := f.Seek(q.readOffset, io.SeekStart); err != nil {
+				f.Close()
+				return 0, fmt.Errorf("failed to seek queue segment %d: %w", seq, err)
+			}
+		}
+
+		for {
+			var length [4]byte
+			if _, err := io.ReadFull(f, length[:]); err != nil {
+				break
+			}
+			size := binary.BigEndian.Uint32(length[:])
+			if _, err := f.Seek(int64(size), io.SeekCurrent); err != nil {
+				f.Close()
+				return 0, fmt.Errorf("failed to scan queue segment %d: %w", seq, err)
+			}
+			count++
+		}
+
+		f.Close()
+	}
+
+	return count, nil
+}
+
+func (q *Queue) segmentPath(seq int) string {
+	return filepath.Join(q.dir, fmt.Sprintf("segment-%010d.log", seq))
+}
+
+func (q *Queue) indexPath() string {
+	return filepath.Join(q.dir, "index")
+}
+
+func (q *Queue) segments() ([]int, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue segments: %w", err)
+	}
+
+	var seqs []int
+	for _, entry := range entries {
+		var seq int
+		if _, err := fmt.Sscanf(entry.Name(), "segment-%d.log", &seq); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+
+	return seqs, nil
+}
+
+func (q *Queue) loadIndex() (int, int64) {
+	data, err := os.ReadFile(q.indexPath())
+	if err != nil {
+		return 0, 0
+	}
+
+	var seq int
+	var offset int64
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(data)), "%d %d", &seq, &offset); err != nil {
+		return 0, 0
+	}
+
+	return seq, offset
+}
+
+func (q *Queue) saveIndex(seq int, offset int64) error {
+	tmp := q.indexPath() + ".tmp"
+	content := strconv.Itoa(seq) + " " + strconv.FormatInt(offset, 10)
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write queue index: %w", err)
+	}
+	return os.Rename(tmp, q.indexPath())
+}
+
+func (q *Queue) openWriter(seq int) error {
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open queue segment %d: %w", seq, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat queue segment %d: %w", seq, err)
+	}
+
+	q.writer = f
+	q.writeSeq = seq
+	q.writeLen = info.Size()
+
+	return nil
+}
+
+// Enqueue appends a payload as a length-prefixed JSON record, rolling to a
+// new segment once the active one crosses queueSegmentBytes. Once the
+// queue's total on-disk size reaches maxBytes it rejects new records
+// instead of growing without bound during a sustained sink outage.
+func (q *Queue) Enqueue(payload WebhookPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued payload: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	recordBytes := int64(4 + len(data))
+	if q.maxBytes > 0 && q.diskBytes+recordBytes > q.maxBytes {
+		return fmt.Errorf("queue full: %d/%d bytes used, dropping event %q", q.diskBytes, q.maxBytes, payload.EventType)
+	}
+
+	if q.writeLen >= queueSegmentBytes {
+		if err := q.writer.Close(); err != nil {
+			return fmt.Errorf("failed to close queue segment %d: %w", q.writeSeq, err)
+		}
+		if err := q.openWriter(q.writeSeq + 1); err != nil {
+			return err
+		}
+	}
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+
+	n, err := q.writer.Write(append(length[:], data...))
+	if err != nil {
+		return fmt.Errorf("failed to append to queue segment %d: %w", q.writeSeq, err)
+	}
+	q.writeLen += int64(n)
+	q.enqueued++
+	q.diskBytes += recordBytes
+
+	return nil
+}
+
+func (q *Queue) openReaderLocked() error {
+	f, err := os.Open(q.segmentPath(q.readSeq))
+	if err != nil {
+		return fmt.Errorf("failed to open queue segment %d: %w", q.readSeq, err)
+	}
+
+	if q.readOffset > 0 {
+		if _, err := f.Seek(q.readOffset, io.SeekStart); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to seek queue segment %d: %w", q.readSeq, err)
+		}
+		q.readOffset = 0
+	}
+
+	q.reader = f
+	return nil
+}
+
+// Dequeue blocks (polling) until a record is available, returning it along
+// with an opaque id to pass to Ack once delivery succeeds.
+func (q *Queue) Dequeue(ctx context.Context) (WebhookPayload, int64, error) {
+	for {
+		payload, id, ok, err := q.tryNext()
+		if err != nil {
+			return WebhookPayload{}, 0, err
+		}
+		if ok {
+			return payload, id, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return WebhookPayload{}, 0, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func (q *Queue) tryNext() (WebhookPayload, int64, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.reader == nil {
+			if err := q.openReaderLocked(); err != nil {
+				return WebhookPayload{}, 0, false, err
+			}
+		}
+
+		var length [4]byte
+		_, err := io.ReadFull(q.reader, length[:])
+		if err == nil {
+			size := binary.BigEndian.Uint32(length[:])
+			data := make([]byte, size)
+			if _, err := io.ReadFull(q.reader, data); err != nil {
+				return WebhookPayload{}, 0, false, fmt.Errorf("failed to read queue record: %w", err)
+			}
+
+			var payload WebhookPayload
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return WebhookPayload{}, 0, false, fmt.Errorf("failed to unmarshal queue record: %w", err)
+			}
+
+			pos, err := q.reader.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return WebhookPayload{}, 0, false, fmt.Errorf("failed to read queue position: %w", err)
+			}
+
+			q.nextID++
+			id := q.nextID
+			q.positions[id] = queuePosition{seq: q.readSeq, offset: pos}
+
+			return payload, id, true, nil
+		}
+
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return WebhookPayload{}, 0, false, fmt.Errorf("failed to read queue segment %d: %w", q.readSeq, err)
+		}
+
+		// No more records in this segment yet. Roll forward if a newer
+		// segment already exists, otherwise wait for one.
+		if q.readSeq >= q.writeSeq {
+			return WebhookPayload{}, 0, false, nil
+		}
+
+		q.reader.Close()
+		q.reader = nil
+		q.readSeq++
+	}
+}
+
+// Ack marks id as durably delivered. The on-disk index only advances past
+// the longest acknowledged prefix, so an out-of-order ack from one worker
+// doesn't let an unacked record from another be skipped on restart.
+func (q *Queue) Ack(id int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.acked[id] = true
+
+	var last queuePosition
+	committed := false
+	for {
+		pos, ok := q.positions[q.nextToCommit]
+		if !ok || !q.acked[q.nextToCommit] {
+			break
+		}
+		last = pos
+		committed = true
+		delete(q.positions, q.nextToCommit)
+		delete(q.acked, q.nextToCommit)
+		q.nextToCommit++
+	}
+
+	if !committed {
+		return nil
+	}
+
+	if err := q.saveIndex(last.seq, last.offset); err != nil {
+		return err
+	}
+
+	return q.reclaimSegmentsLocked(last.seq)
+}
+
+func (q *Queue) reclaimSegmentsLocked(upToSeq int) error {
+	segs, err := q.segments()
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range segs {
+		if seq >= upToSeq {
+			continue
+		}
+
+		path := q.segmentPath(seq)
+		if info, err := os.Stat(path); err == nil {
+			q.diskBytes -= info.Size()
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove consumed queue segment %d: %v", seq, err)
+		}
+	}
+
+	return nil
+}
+
+// Depth reports the number of records enqueued but not yet acknowledged,
+// including ones still sitting on disk waiting to be dequeued - not just
+// ones a worker has already picked up. Workers parked retrying a single
+// record during a sink outage would otherwise leave Depth reporting only
+// the handful of in-flight records while the real on-disk backlog grows
+// unbounded.
+func (q *Queue) Depth() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.enqueued - q.nextToCommit + 1
+}
+
+// QueueingPublisher implements Publisher by appending each payload to a
+// durable on-disk Queue instead of delivering it inline. A QueueWorkerPool
+// drains the queue in the background and delivers to the real Publisher, so
+// a sink outage no longer drops events.
+type QueueingPublisher struct {
+	queue   *Queue
+	metrics *Metrics
+}
+
+func (p *QueueingPublisher) Publish(ctx context.Context, payload WebhookPayload) error {
+	if err := p.queue.Enqueue(payload); err != nil {
+		return err
+	}
+	p.metrics.SetQueueDepth(p.queue.Depth())
+	return nil
+}
+
+func (p *QueueingPublisher) Close() error { return nil }
+
+// QueueWorkerPool drains a Queue with bounded concurrency, delivering each
+// record via Publisher and only acknowledging it once delivery succeeds;
+// failed deliveries retry with exponential backoff.
+type QueueWorkerPool struct {
+	queue     *Queue
+	publisher Publisher
+	metrics   *Metrics
+	workers   int
+}
+
+func newQueueWorkerPool(queue *Queue, publisher Publisher, metrics *Metrics, workers int) *QueueWorkerPool {
+	return &QueueWorkerPool{queue: queue, publisher: publisher, metrics: metrics, workers: workers}
+}
+
+func (wp *QueueWorkerPool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < wp.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wp.worker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (wp *QueueWorkerPool) worker(ctx context.Context) {
+	for {
+		payload, id, err := wp.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("queue worker: failed to read next record: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		wp.metrics.SetQueueDepth(wp.queue.Depth())
+		wp.deliverWithRetry(ctx, payload, id)
+	}
+}
+
+func (wp *QueueWorkerPool) deliverWithRetry(ctx context.Context, payload WebhookPayload, id int64) {
+	delay := time.Second
+	for {
+		if err := deliver(ctx, wp.publisher, wp.metrics, payload); err == nil {
+			if err := wp.queue.Ack(id); err != nil {
+				log.Printf("queue worker: failed to ack record: %v", err)
+			}
+			wp.metrics.SetQueueDepth(wp.queue.Depth())
+			return
+		} else {
+			wp.metrics.AddRetry(1)
+			log.Printf("queue worker: delivery of %q failed, retrying in %s: %v", payload.EventType, delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > 60*time.Second {
+			delay = 60 * time.Second
+		}
+	}
+}
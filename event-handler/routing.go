@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sinkConcurrency bounds how many deliveries to a single sink may be in
+// flight at once, so one slow destination can't starve the others.
+const sinkConcurrency = 4
+
+// BasicAuth holds static HTTP basic-auth credentials for a sink.
+type BasicAuth struct {
+	User string `json:"user" yaml:"user"`
+	Pass string `json:"pass" yaml:"pass"`
+}
+
+// SinkConfig describes one named delivery destination. Body is an optional
+// text/template that renders the event into a custom payload (e.g. a
+// Slack/Telegram/Twilio form POST); when empty the payload is delivered as
+// today's WebhookPayload JSON.
+type SinkConfig struct {
+	Name       string            `json:"name" yaml:"name"`
+	URL        string            `json:"url" yaml:"url"`
+	Method     string            `json:"method" yaml:"method"`
+	BasicAuth  *BasicAuth        `json:"basic_auth,omitempty" yaml:"basic_auth,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	HMACSecret string            `json:"hmac_secret,omitempty" yaml:"hmac_secret,omitempty"`
+	Body       string            `json:"body,omitempty" yaml:"body,omitempty"`
+
+	tmpl *template.Template
+}
+
+// RouteConfig selects events destined for one or more sinks. Events may be
+// filtered by an explicit list or a regex, plus key/value match predicates
+// against the parsed event fields.
+type RouteConfig struct {
+	Source string            `json:"source,omitempty" yaml:"source,omitempty"`
+	Events []string          `json:"events,omitempty" yaml:"events,omitempty"`
+	Regex  string            `json:"regex,omitempty" yaml:"regex,omitempty"`
+	Match  map[string]string `json:"match,omitempty" yaml:"match,omitempty"`
+	Sinks  []string          `json:"sinks" yaml:"sinks"`
+
+	eventRegex *regexp.Regexp
+}
+
+// RoutingConfig is the top-level document loaded from ROUTES_CONFIG.
+type RoutingConfig struct {
+	Sinks  []SinkConfig  `json:"sinks" yaml:"sinks"`
+	Routes []RouteConfig `json:"routes" yaml:"routes"`
+}
+
+func loadRoutingConfig(path string) (*RoutingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes config %s: %w", path, err)
+	}
+
+	var cfg RoutingConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse routes config %s: %w", path, err)
+	}
+
+	for i := range cfg.Routes {
+		if cfg.Routes[i].Regex == "" {
+			continue
+		}
+		re, err := regexp.Compile(cfg.Routes[i].Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in route %d: %w", i, err)
+		}
+		cfg.Routes[i].eventRegex = re
+	}
+
+	for i := range cfg.Sinks {
+		sink := &cfg.Sinks[i]
+		if sink.Method == "" {
+			sink.Method = "POST"
+		}
+		if sink.Body == "" {
+			continue
+		}
+		tmpl, err := template.New(sink.Name).Parse(sink.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template for sink %s: %w", sink.Name, err)
+		}
+		sink.tmpl = tmpl
+	}
+
+	return &cfg, nil
+}
+
+// Router implements Publisher by fanning an event out to every sink whose
+// route matches, each in its own goroutine with bounded per-sink
+// concurrency, so delivery never blocks the ingest loop.
+type Router struct {
+	sinks      map[string]*SinkConfig
+	routes     []RouteConfig
+	limiters   map[string]chan struct{}
+	httpClient *http.Client
+}
+
+func newRouter(path string) (*Router, error) {
+	cfg, err := loadRoutingConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Router{
+		sinks:      make(map[string]*SinkConfig),
+		routes:     cfg.Routes,
+		limiters:   make(map[string]chan struct{}),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for i := range cfg.Sinks {
+		sink := &cfg.Sinks[i]
+		r.sinks[sink.Name] = sink
+		r.limiters[sink.Name] = make(chan struct{}, sinkConcurrency)
+	}
+
+	return r, nil
+}
+
+func matchesRoute(route RouteConfig, source string, payload WebhookPayload) bool {
+	if route.Source != "" && route.Source != source {
+		return false
+	}
+
+	if len(route.Events) > 0 {
+		found := false
+		for _, e := range route.Events {
+			if e == payload.EventType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	} else if route.eventRegex != nil && !route.eventRegex.MatchString(payload.EventType) {
+		return false
+	}
+
+	for key, want := range route.Match {
+		if fmt.Sprintf("%v", fieldValue(payload.Data, key)) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+func fieldValue(data interface{}, key string) interface{} {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return m[key]
+}
+
+// Publish fans the payload out to every matching sink and waits for all
+// deliveries to finish. It returns an error unless at least one sink
+// accepted the event, so a caller using the Router as the backing
+// Publisher (e.g. the queue) only acks on genuine delivery.
+func (r *Router) Publish(ctx context.Context, payload WebhookPayload) error {
+	source := "ami"
+	if strings.HasSuffix(payload.Source, "ari") {
+		source = "ari"
+	}
+
+	dispatched := make(map[string]bool)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var delivered, attempted int
+	for _, route := range r.routes {
+		if !matchesRoute(route, source, payload) {
+			continue
+		}
+		for _, name := range route.Sinks {
+			if dispatched[name] {
+				continue
+			}
+			dispatched[name] = true
+
+			sink, ok := r.sinks[name]
+			if !ok {
+				log.Printf("route references unknown sink %q, skipping", name)
+				continue
+			}
+
+			attempted++
+			wg.Add(1)
+			go func(sink *SinkConfig) {
+				defer wg.Done()
+				ok := r.deliver(ctx, sink, payload)
+				mu.Lock()
+				if ok {
+					delivered++
+				}
+				mu.Unlock()
+			}(sink)
+		}
+	}
+	wg.Wait()
+
+	if attempted > 0 && delivered == 0 {
+		return fmt.Errorf("routing: all %d sink deliveries failed for event %q", attempted, payload.EventType)
+	}
+
+	return nil
+}
+
+func (r *Router) Close() error { return nil }
+
+// deliver sends payload to sink and reports whether it was accepted (2xx).
+func (r *Router) deliver(ctx context.Context, sink *SinkConfig, payload WebhookPayload) bool {
+	limiter := r.limiters[sink.Name]
+	limiter <- struct{}{}
+	defer func() { <-limiter }()
+
+	body, err := renderSinkBody(sink, payload)
+	if err != nil {
+		log.Printf("sink %q: failed to render body: %v", sink.Name, err)
+		return false
+	}
+
+	req, err := http.NewRequestWithContext(ctx, sink.Method, sink.URL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("sink %q: failed to build request: %v", sink.Name, err)
+		return false
+	}
+
+	if sink.tmpl == nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range sink.Headers {
+		req.Header.Set(key, value)
+	}
+	if sink.BasicAuth != nil {
+		req.SetBasicAuth(sink.BasicAuth.User, sink.BasicAuth.Pass)
+	}
+	if sink.HMACSecret != "" {
+		mac := hmac.New(sha256.New, []byte(sink.HMACSecret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		log.Printf("sink %q: delivery failed: %v", sink.Name, err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("sink %q: returned non-success status %d", sink.Name, resp.StatusCode)
+		return false
+	}
+
+	log.Printf("sink %q: delivered event %q", sink.Name, payload.EventType)
+	return true
+}
+
+func renderSinkBody(sink *SinkConfig, payload WebhookPayload) ([]byte, error) {
+	if sink.tmpl == nil {
+		return json.Marshal(payload)
+	}
+
+	var buf bytes.Buffer
+	if err := sink.tmpl.Execute(&buf, payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Supervisor runs a connect-and-serve loop with exponential backoff,
+// reconnecting on every failure instead of letting the process give up.
+// Backoff resets once a connection has stayed up past healthyAfter.
+type Supervisor struct {
+	name         string
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	healthyAfter time.Duration
+	metrics      *Metrics
+}
+
+func newSupervisor(name string, metrics *Metrics) *Supervisor {
+	return &Supervisor{
+		name:         name,
+		baseDelay:    time.Second,
+		maxDelay:     60 * time.Second,
+		healthyAfter: 60 * time.Second,
+		metrics:      metrics,
+	}
+}
+
+// Run calls connect repeatedly until ctx is cancelled. connect should block
+// for the lifetime of one connection (performing login/registration, then
+// serving) and return the error that ended it.
+func (s *Supervisor) Run(ctx context.Context, connect func(ctx context.Context) error) {
+	delay := s.baseDelay
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.metrics.SetConnected(s.name, true)
+		connectedAt := time.Now()
+		err := connect(ctx)
+		s.metrics.SetConnected(s.name, false)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.Printf("%s: connection lost: %v", s.name, err)
+		}
+
+		if time.Since(connectedAt) > s.healthyAfter {
+			delay = s.baseDelay
+		}
+
+		wait := delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+		log.Printf("%s: reconnecting in %s", s.name, wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+		if delay > s.maxDelay {
+			delay = s.maxDelay
+		}
+	}
+}
+
+// Metrics tracks the state this subsystem exposes via /healthz and
+// /metrics: per-source connection state, queue depth, retry counts, and
+// per-event-type throughput.
+type Metrics struct {
+	mu          sync.Mutex
+	connected   map[string]bool
+	eventCounts map[string]int64
+	queueDepth  int64
+	retryCount  int64
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		connected:   make(map[string]bool),
+		eventCounts: make(map[string]int64),
+	}
+}
+
+func (m *Metrics) SetConnected(source string, connected bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected[source] = connected
+}
+
+func (m *Metrics) IncEvent(eventType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eventCounts[eventType]++
+}
+
+func (m *Metrics) AddRetry(n int64) {
+	atomic.AddInt64(&m.retryCount, n)
+}
+
+func (m *Metrics) SetQueueDepth(depth int64) {
+	atomic.StoreInt64(&m.queueDepth, depth)
+}
+
+func (m *Metrics) snapshot() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	connected := make(map[string]bool, len(m.connected))
+	for k, v := range m.connected {
+		connected[k] = v
+	}
+
+	eventCounts := make(map[string]int64, len(m.eventCounts))
+	for k, v := range m.eventCounts {
+		eventCounts[k] = v
+	}
+
+	return map[string]interface{}{
+		"connected":    connected,
+		"queue_depth":  atomic.LoadInt64(&m.queueDepth),
+		"retry_count":  atomic.LoadInt64(&m.retryCount),
+		"event_counts": eventCounts,
+	}
+}
+
+func (m *Metrics) writePrometheus(w http.ResponseWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP asterisk_forwarder_connected Connection state (1=connected) per source")
+	fmt.Fprintln(w, "# TYPE asterisk_forwarder_connected gauge")
+	for source, connected := range m.connected {
+		value := 0
+		if connected {
+			value = 1
+		}
+		fmt.Fprintf(w, "asterisk_forwarder_connected{source=%q} %d\n", source, value)
+	}
+
+	fmt.Fprintln(w, "# HELP asterisk_forwarder_queue_depth Events queued awaiting delivery")
+	fmt.Fprintln(w, "# TYPE asterisk_forwarder_queue_depth gauge")
+	fmt.Fprintf(w, "asterisk_forwarder_queue_depth %d\n", atomic.LoadInt64(&m.queueDepth))
+
+	fmt.Fprintln(w, "# HELP asterisk_forwarder_retries_total Delivery retries across all sinks")
+	fmt.Fprintln(w, "# TYPE asterisk_forwarder_retries_total counter")
+	fmt.Fprintf(w, "asterisk_forwarder_retries_total %d\n", atomic.LoadInt64(&m.retryCount))
+
+	fmt.Fprintln(w, "# HELP asterisk_forwarder_events_total Events processed per type")
+	fmt.Fprintln(w, "# TYPE asterisk_forwarder_events_total counter")
+	for eventType, count := range m.eventCounts {
+		fmt.Fprintf(w, "asterisk_forwarder_events_total{event_type=%q} %d\n", eventType, count)
+	}
+}
+
+// Start serves /healthz and /metrics on addr. Meant to run in its own
+// goroutine; it blocks until the listener fails.
+func (m *Metrics) Start(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, m.snapshot())
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.writePrometheus(w)
+	})
+
+	log.Printf("Metrics listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Metrics server stopped: %v", err)
+	}
+}